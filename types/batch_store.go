@@ -24,7 +24,7 @@ type BatchStore interface {
 // Hints are a set of hashes that should be used to speed up the validation of one or more Chunks.
 type Hints map[ref.Ref]struct{}
 
-// BatchStoreAdaptor provides a naive implementation of BatchStore should only be used with ChunkStores that can Put relatively quickly. It provides no actual batching or validation. Its intended use is for adapting a ChunkStore for use in something that requires a BatchStore.
+// BatchStoreAdaptor provides a naive implementation of BatchStore should only be used with ChunkStores that can Put relatively quickly. It provides no actual batching or validation. Its intended use is for adapting a ChunkStore for use in something that requires a BatchStore. For a BatchStore that actually batches, validates against Hints and applies backpressure, see BatchStoreImpl.
 type BatchStoreAdaptor struct {
 	cs chunks.ChunkStore
 }
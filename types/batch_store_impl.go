@@ -0,0 +1,259 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/attic-labs/noms/chunks"
+	"github.com/attic-labs/noms/d"
+	"github.com/attic-labs/noms/ref"
+)
+
+// BatchStoreStats reports how many chunks a BatchStoreImpl has scheduled,
+// actually flushed to the backing ChunkStore, and deduplicated away because
+// an equal chunk was already waiting in the current batch.
+type BatchStoreStats struct {
+	Scheduled int
+	Flushed   int
+	Deduped   int
+}
+
+type pendingPut struct {
+	c     chunks.Chunk
+	hints Hints
+}
+
+// BatchStoreImpl is a production BatchStore. Unlike BatchStoreAdaptor, it
+// actually batches: SchedulePut buffers chunks until a size- or
+// count-bound is hit, then flushes the batch through a pool of worker
+// goroutines. The SchedulePut call that fills a batch blocks until every
+// Put of that batch is done -- that is the backpressure -- while calls
+// that don't fill it return without waiting. Every chunk is validated
+// against its Hints before being handed to the store, and a ref scheduled
+// more than once within the same batch is coalesced rather than written
+// twice.
+type BatchStoreImpl struct {
+	cs chunks.ChunkStore
+
+	maxCount int
+	maxBytes int
+	workers  int
+
+	mu sync.Mutex
+	// pending and pendingRefs always move together: pendingRefs is the ref
+	// set of pending, scoping dedup to the chunks currently awaiting flush
+	// rather than to this BatchStoreImpl's whole lifetime. Both are reset
+	// together at the start of flush, so a chunk that fails validation and
+	// is never Put can simply be rescheduled afterwards.
+	pending     []pendingPut
+	pendingRefs map[ref.Ref]struct{}
+	bytes       int
+
+	statsMu sync.Mutex
+	stats   BatchStoreStats
+}
+
+// NewBatchStoreImpl returns a BatchStore backed by cs that batches up to
+// maxCount chunks or maxBytes bytes of chunk data, whichever bound is hit
+// first, flushing each batch through up to workers concurrent Puts.
+func NewBatchStoreImpl(cs chunks.ChunkStore, maxCount, maxBytes, workers int) *BatchStoreImpl {
+	d.Chk.True(maxCount > 0 && maxBytes > 0 && workers > 0, "maxCount, maxBytes and workers must all be positive")
+	return &BatchStoreImpl{
+		cs:          cs,
+		maxCount:    maxCount,
+		maxBytes:    maxBytes,
+		workers:     workers,
+		pendingRefs: map[ref.Ref]struct{}{},
+	}
+}
+
+// Get simply proxies to the backing ChunkStore.
+func (bs *BatchStoreImpl) Get(r ref.Ref) chunks.Chunk {
+	return bs.cs.Get(r)
+}
+
+// SchedulePut enqueues c, coalescing it away if an equal chunk is already
+// waiting in the current batch. If enqueuing c fills the batch,
+// SchedulePut flushes synchronously and blocks until that flush completes,
+// which is how backpressure reaches the caller.
+func (bs *BatchStoreImpl) SchedulePut(c chunks.Chunk, hints Hints) {
+	bs.recordScheduled()
+
+	bs.mu.Lock()
+	r := c.Ref()
+	if _, ok := bs.pendingRefs[r]; ok {
+		bs.mu.Unlock()
+		bs.recordDeduped()
+		return
+	}
+	bs.pendingRefs[r] = struct{}{}
+	bs.pending = append(bs.pending, pendingPut{c, hints})
+	bs.bytes += len(c.Data())
+	full := len(bs.pending) >= bs.maxCount || bs.bytes >= bs.maxBytes
+	bs.mu.Unlock()
+
+	if full {
+		// A synchronous validation failure here means the caller handed
+		// us an invalid chunk; that's a programming error, not something
+		// SchedulePut's error-less signature can report, so we treat it
+		// the same as any other invariant violation in this package.
+		d.Chk.NoError(bs.flush(context.Background()))
+	}
+}
+
+// Flush persists every chunk scheduled so far, blocking until it's done.
+func (bs *BatchStoreImpl) Flush() {
+	d.Chk.NoError(bs.flush(context.Background()))
+}
+
+// FlushWithContext is the Flush(ctx) variant for large ingests: it returns
+// the first validation error encountered instead of panicking. Once ctx is
+// done it starts no further Puts and reports ctx.Err() if no validation
+// error came first, but it still waits for the Puts already in flight.
+func (bs *BatchStoreImpl) FlushWithContext(ctx context.Context) error {
+	return bs.flush(ctx)
+}
+
+// Stats returns a snapshot of this BatchStoreImpl's lifetime counters.
+func (bs *BatchStoreImpl) Stats() BatchStoreStats {
+	bs.statsMu.Lock()
+	defer bs.statsMu.Unlock()
+	return bs.stats
+}
+
+// Close flushes any remaining chunks, then closes the backing ChunkStore.
+func (bs *BatchStoreImpl) Close() error {
+	bs.Flush()
+	return bs.cs.Close()
+}
+
+func (bs *BatchStoreImpl) flush(ctx context.Context) error {
+	bs.mu.Lock()
+	batch := bs.pending
+	bs.pending = nil
+	bs.pendingRefs = map[ref.Ref]struct{}{}
+	bs.bytes = 0
+	bs.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	inBatch := make(map[ref.Ref]struct{}, len(batch))
+	for _, p := range batch {
+		inBatch[p.c.Ref()] = struct{}{}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, bs.workers)
+
+	for _, p := range batch {
+		p := p
+		if err := bs.validate(p.c, p.hints, inBatch); err != nil {
+			errOnce.Do(func() { firstErr = err })
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errOnce.Do(func() { firstErr = ctx.Err() })
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			bs.cs.Put(p.c)
+			bs.recordFlushed()
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// validate checks that every ref embedded in c is either in this same
+// batch, already persisted in the backing ChunkStore, or transitively
+// reachable from a hint -- actually present somewhere in that hinted
+// chunk's ref graph, not merely type-compatible with it -- at a point
+// whose declared type is a supertype of the embedded ref's.
+func (bs *BatchStoreImpl) validate(c chunks.Chunk, hints Hints, inBatch map[ref.Ref]struct{}) error {
+	v := DecodeValue(c, nil)
+	for _, r := range v.Chunks() {
+		if _, ok := inBatch[r.TargetRef()]; ok {
+			continue
+		}
+		if !bs.cs.Get(r.TargetRef()).IsEmpty() {
+			continue
+		}
+		if bs.hintCovers(r, hints) {
+			continue
+		}
+		return fmt.Errorf("chunk %s embeds ref %s that is neither in-batch, already stored, nor covered by a hint", c.Ref(), r.TargetRef())
+	}
+	return nil
+}
+
+func (bs *BatchStoreImpl) hintCovers(r Ref, hints Hints) bool {
+	for h := range hints {
+		hinted := bs.cs.Get(h)
+		if hinted.IsEmpty() {
+			continue
+		}
+		if bs.reachableFrom(DecodeValue(hinted, nil), r, map[ref.Ref]struct{}{}) {
+			return true
+		}
+	}
+	return false
+}
+
+// reachableFrom walks v's ref graph -- following embedded refs into further
+// chunks read from the backing store -- looking for target. visited guards
+// against revisiting a chunk reachable by more than one path. A match only
+// counts if target's declared type is a subtype of the type it's embedded
+// as, i.e. the hint actually vouches for the kind of value target claims
+// to be.
+func (bs *BatchStoreImpl) reachableFrom(v Value, target Ref, visited map[ref.Ref]struct{}) bool {
+	for _, child := range v.Chunks() {
+		if child.TargetRef() == target.TargetRef() {
+			return target.Type().IsSubtypeOf(child.Type())
+		}
+		if _, ok := visited[child.TargetRef()]; ok {
+			continue
+		}
+		visited[child.TargetRef()] = struct{}{}
+
+		childChunk := bs.cs.Get(child.TargetRef())
+		if childChunk.IsEmpty() {
+			continue
+		}
+		if bs.reachableFrom(DecodeValue(childChunk, nil), target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bs *BatchStoreImpl) recordScheduled() {
+	bs.statsMu.Lock()
+	bs.stats.Scheduled++
+	bs.statsMu.Unlock()
+}
+
+func (bs *BatchStoreImpl) recordFlushed() {
+	bs.statsMu.Lock()
+	bs.stats.Flushed++
+	bs.statsMu.Unlock()
+}
+
+func (bs *BatchStoreImpl) recordDeduped() {
+	bs.statsMu.Lock()
+	bs.stats.Deduped++
+	bs.statsMu.Unlock()
+}
@@ -0,0 +1,59 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/chunks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchStoreImplDedupesScheduledChunks(t *testing.T) {
+	assert := assert.New(t)
+
+	cs := chunks.NewMemoryStore()
+	bs := NewBatchStoreImpl(cs, 10, 1<<20, 2)
+
+	c := chunks.NewChunk([]byte("same-data"))
+	bs.SchedulePut(c, Hints{})
+	bs.SchedulePut(c, Hints{})
+	bs.Flush()
+
+	stats := bs.Stats()
+	assert.Equal(2, stats.Scheduled)
+	assert.Equal(1, stats.Flushed)
+	assert.Equal(1, stats.Deduped)
+}
+
+func TestBatchStoreImplFlushesOnCountBound(t *testing.T) {
+	assert := assert.New(t)
+
+	cs := chunks.NewMemoryStore()
+	bs := NewBatchStoreImpl(cs, 2, 1<<20, 2)
+
+	bs.SchedulePut(chunks.NewChunk([]byte("a")), Hints{})
+	bs.SchedulePut(chunks.NewChunk([]byte("b")), Hints{})
+
+	assert.Equal(2, bs.Stats().Flushed, "hitting maxCount should flush without an explicit Flush() call")
+}
+
+func TestBatchStoreImplDedupeDoesNotOutliveItsBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	cs := chunks.NewMemoryStore()
+	bs := NewBatchStoreImpl(cs, 10, 1<<20, 2)
+
+	c := chunks.NewChunk([]byte("same-data"))
+	bs.SchedulePut(c, Hints{})
+	bs.Flush()
+
+	// c was already flushed in the previous batch, so scheduling it again
+	// starts a brand new batch rather than being silently coalesced away
+	// against a store-lifetime record of refs already seen.
+	bs.SchedulePut(c, Hints{})
+	bs.Flush()
+
+	stats := bs.Stats()
+	assert.Equal(2, stats.Scheduled)
+	assert.Equal(2, stats.Flushed)
+	assert.Equal(0, stats.Deduped)
+}
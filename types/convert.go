@@ -0,0 +1,260 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ConvertOptions controls how far types.Convert is willing to go to make a
+// Value fit a target Type. The zero value only allows conversions that can
+// never lose information or silently fabricate data.
+type ConvertOptions struct {
+	// AllowLossy permits conversions that can truncate or reinterpret data,
+	// e.g. String -> Number, or Number -> Bool via zero/non-zero.
+	AllowLossy bool
+
+	// AllowMissingFields permits a Struct -> Struct conversion to proceed
+	// even when the target declares a field the source doesn't have; the
+	// field is simply omitted from the result rather than erroring.
+	AllowMissingFields bool
+}
+
+// Convert attempts to produce a Value of type target from v, using only
+// conversions that cannot lose information. It is equivalent to calling
+// ConvertWithOptions(v, target, ConvertOptions{}).
+func Convert(v Value, target *Type) (Value, error) {
+	return ConvertWithOptions(v, target, ConvertOptions{})
+}
+
+// ConvertWithOptions converts v to the given target Type, following the
+// conversion table built on top of IsSubtypeOf:
+//   - if v's type is already a subtype of target, v is returned unchanged,
+//     except for a Struct target, which always goes through the projection
+//     below
+//   - primitive -> primitive widening (String, Number, Bool) is attempted
+//     next, gated by opts.AllowLossy for anything that can lose precision
+//     or meaning
+//   - List<A> -> List<B>, Set<A> -> Set<B> and Map<K1,V1> -> Map<K2,V2> are
+//     performed lazily: the result wraps the source sequence and converts
+//     each element on read, rather than copying eagerly
+//   - Struct{...} -> Struct{...} projects matching field names and drops
+//     fields the target doesn't declare; opts.AllowMissingFields controls
+//     whether the reverse -- a field the target wants but the source lacks
+//     -- is tolerated
+//   - T -> Union picks whichever arm v's runtime type already satisfies, or
+//     failing that, the first arm a conversion into succeeds for (lossy
+//     conversions only if opts.AllowLossy)
+//
+// An error is returned if no rule applies, or if a lossy rule would be
+// needed but opts.AllowLossy is false.
+func ConvertWithOptions(v Value, target *Type, opts ConvertOptions) (Value, error) {
+	if v.Type().IsSubtypeOf(target) && target.Kind() != StructKind {
+		return v, nil
+	}
+
+	// A union-typed source doesn't carry its declared type on the runtime
+	// Value -- v.Type() reflects whichever arm it actually is -- so there is
+	// nothing left to dispatch on here; v.Type().IsSubtypeOf(target) above
+	// already would have matched if the runtime arm were already acceptable.
+	// What's left to handle is a union-typed *target*: convert v to
+	// whichever arm it already satisfies.
+	if targetUnion, ok := target.Desc.(CompoundDesc); ok && targetUnion.Kind() == UnionKind {
+		return convertToUnion(v, targetUnion, opts)
+	}
+
+	switch target.Kind() {
+	case BoolKind, NumberKind, StringKind:
+		return convertPrimitive(v, target, opts)
+	case ListKind, SetKind, MapKind:
+		return convertCollection(v, target, opts)
+	case StructKind:
+		return convertStruct(v, target, opts)
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to %s", v.Type().Describe(), target.Describe())
+}
+
+// convertToUnion converts v to whichever arm of targetUnion it can be
+// converted to, preferring an arm v.Type() is already a subtype of (no
+// conversion needed) before attempting a conversion into any arm, lossy
+// only if opts.AllowLossy.
+func convertToUnion(v Value, targetUnion CompoundDesc, opts ConvertOptions) (Value, error) {
+	for _, arm := range targetUnion.ElemTypes {
+		if v.Type().IsSubtypeOf(arm) {
+			return v, nil
+		}
+	}
+	for _, arm := range targetUnion.ElemTypes {
+		if converted, err := ConvertWithOptions(v, arm, opts); err == nil {
+			return converted, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %s to any arm of %s", v.Type().Describe(), MakeUnionType(targetUnion.ElemTypes...).Describe())
+}
+
+func convertPrimitive(v Value, target *Type, opts ConvertOptions) (Value, error) {
+	switch src := v.(type) {
+	case String:
+		switch target.Kind() {
+		case NumberKind:
+			if !opts.AllowLossy {
+				return nil, fmt.Errorf("String -> Number requires ConvertOptions.AllowLossy")
+			}
+			n, err := strconv.ParseFloat(string(src), 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %q to Number: %v", string(src), err)
+			}
+			return Number(n), nil
+		}
+	case Number:
+		switch target.Kind() {
+		case StringKind:
+			return String(strconv.FormatFloat(float64(src), 'g', -1, 64)), nil
+		case BoolKind:
+			if !opts.AllowLossy {
+				return nil, fmt.Errorf("Number -> Bool requires ConvertOptions.AllowLossy")
+			}
+			return Bool(src != 0), nil
+		}
+	case Bool:
+		switch target.Kind() {
+		case NumberKind:
+			if src {
+				return Number(1), nil
+			}
+			return Number(0), nil
+		case StringKind:
+			if !opts.AllowLossy {
+				return nil, fmt.Errorf("Bool -> String requires ConvertOptions.AllowLossy")
+			}
+			return String(strconv.FormatBool(bool(src))), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %s to %s", v.Type().Describe(), target.Describe())
+}
+
+func convertCollection(v Value, target *Type, opts ConvertOptions) (Value, error) {
+	if v.Type().Kind() != target.Kind() {
+		return nil, fmt.Errorf("cannot convert %s to %s", v.Type().Describe(), target.Describe())
+	}
+
+	switch src := v.(type) {
+	case List:
+		elemType := target.Desc.(CompoundDesc).ElemTypes[0]
+		return newConvertingList(src, elemType, opts), nil
+	case Set:
+		elemType := target.Desc.(CompoundDesc).ElemTypes[0]
+		return newConvertingSet(src, elemType, opts), nil
+	case Map:
+		elemTypes := target.Desc.(CompoundDesc).ElemTypes
+		return newConvertingMap(src, elemTypes[0], elemTypes[1], opts), nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to %s", v.Type().Describe(), target.Describe())
+}
+
+func convertStruct(v Value, target *Type, opts ConvertOptions) (Value, error) {
+	src, ok := v.(Struct)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %s to %s", v.Type().Describe(), target.Describe())
+	}
+
+	targetDesc := target.Desc.(StructDesc)
+	fields := TypeMap{}
+	values := map[string]Value{}
+	missingRequired := false
+	var convertErr error
+	targetDesc.IterFields(func(name string, fieldType *Type, opt FieldOptions) {
+		if convertErr != nil {
+			return
+		}
+		fieldVal, present := src.MaybeGet(name)
+		if !present {
+			if !opt.Optional {
+				missingRequired = true
+			}
+			return
+		}
+		converted, err := ConvertWithOptions(fieldVal, fieldType, opts)
+		if err != nil {
+			convertErr = fmt.Errorf("field %q: %v", name, err)
+			return
+		}
+		fields[name] = fieldType
+		values[name] = converted
+	})
+	if convertErr != nil {
+		return nil, convertErr
+	}
+	if missingRequired && !opts.AllowMissingFields {
+		return nil, fmt.Errorf("source struct is missing a field required by %s", target.Describe())
+	}
+
+	return NewStruct(targetDesc.Name, fields, values), nil
+}
+
+// Unify returns the most specific Type that is a supertype of both a and
+// b, so that callers combining heterogeneous inputs (e.g. before writing
+// them through BatchStore) can normalize to a single type rather than
+// rejecting the batch outright.
+func Unify(a, b *Type) (*Type, error) {
+	if a.IsSubtypeOf(b) {
+		return b, nil
+	}
+	if b.IsSubtypeOf(a) {
+		return a, nil
+	}
+
+	if a.Kind() == b.Kind() {
+		switch aDesc := a.Desc.(type) {
+		case CompoundDesc:
+			bDesc := b.Desc.(CompoundDesc)
+			if aDesc.Kind() == UnionKind {
+				return unifyUnions(aDesc, bDesc), nil
+			}
+			// Every other CompoundDesc (List, Set, Map, Ref) has a fixed,
+			// kind-determined arity, so matching ElemTypes by index is safe.
+			elemTypes := make([]*Type, len(aDesc.ElemTypes))
+			for i, aElem := range aDesc.ElemTypes {
+				unified, err := Unify(aElem, bDesc.ElemTypes[i])
+				if err != nil {
+					return nil, err
+				}
+				elemTypes[i] = unified
+			}
+			return buildType(CompoundDesc{aDesc.Kind(), elemTypes}), nil
+		}
+	}
+
+	// Neither is a subtype of the other and they aren't compatible
+	// compounds of the same kind, so the only common supertype left is a
+	// union of the two.
+	return MakeUnionType(a, b), nil
+}
+
+// unifyUnions merges the arm sets of two unions rather than matching arms
+// by index -- unions aren't required to have the same arity, and even when
+// they do, positional matching has no relation to which arms are actually
+// compatible.
+func unifyUnions(a, b CompoundDesc) *Type {
+	arms := append(append([]*Type{}, a.ElemTypes...), b.ElemTypes...)
+	return MakeUnionType(dedupeArms(arms)...)
+}
+
+// dedupeArms drops arms that already appear (by Equals) earlier in arms, so
+// merging two unions' arm sets doesn't produce a union with a repeated arm.
+func dedupeArms(arms []*Type) []*Type {
+	kept := make([]*Type, 0, len(arms))
+	for _, arm := range arms {
+		redundant := false
+		for _, k := range kept {
+			if arm.Equals(k) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			kept = append(kept, arm)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,141 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertIdentitySubtype(t *testing.T) {
+	assert := assert.New(t)
+
+	v, err := Convert(Number(42), ValueType)
+	assert.NoError(err)
+	assert.Equal(Number(42), v)
+}
+
+func TestConvertPrimitives(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Convert(String("42"), NumberType)
+	assert.Error(err, "lossy conversions require AllowLossy")
+
+	v, err := ConvertWithOptions(String("42"), NumberType, ConvertOptions{AllowLossy: true})
+	assert.NoError(err)
+	assert.Equal(Number(42), v)
+
+	v, err = Convert(Number(42), StringType)
+	assert.NoError(err)
+	assert.Equal(String("42"), v)
+
+	v, err = Convert(Bool(true), NumberType)
+	assert.NoError(err)
+	assert.Equal(Number(1), v)
+}
+
+func TestUnifyPrimitives(t *testing.T) {
+	assert := assert.New(t)
+
+	u, err := Unify(NumberType, NumberType)
+	assert.NoError(err)
+	assert.True(u.Equals(NumberType))
+
+	u, err = Unify(NumberType, ValueType)
+	assert.NoError(err)
+	assert.True(u.Equals(ValueType))
+
+	u, err = Unify(NumberType, StringType)
+	assert.NoError(err)
+	assert.True(u.Equals(MakeUnionType(NumberType, StringType)))
+}
+
+func TestUnifyCompound(t *testing.T) {
+	assert := assert.New(t)
+
+	u, err := Unify(MakeListType(NumberType), MakeListType(StringType))
+	assert.NoError(err)
+	assert.True(u.Equals(MakeListType(MakeUnionType(NumberType, StringType))))
+}
+
+func TestUnifyUnionsOfDifferentArity(t *testing.T) {
+	assert := assert.New(t)
+
+	a := MakeUnionType(NumberType, StringType)
+	b := MakeUnionType(NumberType, BoolType, BlobType)
+
+	u, err := Unify(a, b)
+	assert.NoError(err)
+	assert.True(u.Equals(MakeUnionType(NumberType, StringType, BoolType, BlobType)))
+}
+
+func TestUnifyUnionsOfSameArity(t *testing.T) {
+	assert := assert.New(t)
+
+	a := MakeUnionType(NumberType, StringType)
+	b := MakeUnionType(NumberType, BoolType)
+
+	u, err := Unify(a, b)
+	assert.NoError(err)
+	assert.True(u.Equals(MakeUnionType(NumberType, StringType, BoolType)))
+}
+
+func TestConvertToUnion(t *testing.T) {
+	assert := assert.New(t)
+
+	target := MakeUnionType(NumberType, BoolType)
+
+	v, err := Convert(Number(42), target)
+	assert.NoError(err, "already a subtype of the Number arm, no conversion needed")
+	assert.Equal(Number(42), v)
+
+	v, err = ConvertWithOptions(String("42"), target, ConvertOptions{AllowLossy: true})
+	assert.NoError(err)
+	assert.Equal(Number(42), v)
+
+	_, err = Convert(String("42"), target)
+	assert.Error(err, "String -> Number into the Number arm requires AllowLossy")
+}
+
+func TestConvertStructConvertsChangedFieldTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	targetType := MakeStructType("S", TypeMap{"x": NumberType})
+	source := NewStruct("S", TypeMap{"x": StringType}, map[string]Value{"x": String("42")})
+
+	_, err := Convert(source, targetType)
+	assert.Error(err, "field x is String -> Number, which requires AllowLossy")
+
+	converted, err := ConvertWithOptions(source, targetType, ConvertOptions{AllowLossy: true})
+	assert.NoError(err)
+
+	result, ok := converted.(Struct)
+	assert.True(ok)
+	assert.True(result.Type().Equals(targetType))
+
+	x, present := result.MaybeGet("x")
+	assert.True(present)
+	assert.Equal(Number(42), x, "the field's value, not just its declared type, must have been converted")
+}
+
+func TestConvertStructProjectsMatchingFields(t *testing.T) {
+	assert := assert.New(t)
+
+	targetType := MakeStructType("S", TypeMap{"a": NumberType})
+	source := NewStruct("S", TypeMap{
+		"a": NumberType,
+		"b": StringType,
+	}, map[string]Value{
+		"a": Number(1),
+		"b": String("dropped"),
+	})
+
+	converted, err := Convert(source, targetType)
+	assert.NoError(err)
+
+	result := converted.(Struct)
+	_, present := result.MaybeGet("b")
+	assert.False(present, "fields the target doesn't declare are dropped")
+	a, present := result.MaybeGet("a")
+	assert.True(present)
+	assert.Equal(Number(1), a)
+}
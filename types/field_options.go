@@ -0,0 +1,77 @@
+package types
+
+import "strings"
+
+// FieldOptions holds the per-field metadata MakeStructType can attach to a
+// struct field in addition to its Type.
+//
+// Optional participates in the type system: IsSubtypeOf allows a struct
+// that omits an optional field to remain a subtype of one that declares it
+// (see computeSubtypeOf). It is meant to also give optional fields a
+// present-bit in the encoded form, rather than always occupying a slot, so
+// that chunks encoded before a field became optional still decode -- but
+// that encoder lives outside this package snapshot and hasn't been updated
+// yet, so today FieldOptions exists only in memory: it doesn't survive a
+// round trip through encoding, and (per the note on Type.Ref) isn't part of
+// a struct type's hash either.
+//
+// Tag is opaque to the type system -- it is never consulted by IsSubtypeOf
+// or Convert, though Equals does tell apart two struct types that differ
+// only in a field's Tag -- and is meant to round-trip through the
+// encoding the same way Go struct tags do, for callers that want to attach
+// their own metadata (validation rules, display hints, etc). Like
+// Optional, that round-trip doesn't exist yet for the same reason.
+type FieldOptions struct {
+	Optional bool
+	Tag      string
+}
+
+// Tags parses Tag into key=value pairs, mirroring the convention used by Go
+// struct tags. Segments without an '=' are skipped: Tag is opaque to the
+// type system, and a caller reading it is expected to know its own shape.
+func (o FieldOptions) Tags() map[string]string {
+	tags := map[string]string{}
+	for _, part := range strings.Fields(o.Tag) {
+		if k, v, ok := splitTag(part); ok {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+func splitTag(part string) (k, v string, ok bool) {
+	i := strings.IndexByte(part, '=')
+	if i < 0 {
+		return "", "", false
+	}
+	return part[:i], part[i+1:], true
+}
+
+// fieldOptions returns the FieldOptions for name, or the zero value
+// (required, untagged) if s has no metadata for it -- which is always the
+// case for structs built before FieldOptions existed, so old chunks decode
+// as all-required with empty tags.
+func (s StructDesc) fieldOptions(name string) FieldOptions {
+	if s.FieldOptions == nil {
+		return FieldOptions{}
+	}
+	return s.FieldOptions[name]
+}
+
+// fieldOptionsEqual reports whether a and b attach the same FieldOptions to
+// every field name, treating a nil map the same as an empty one so a
+// struct built before FieldOptions existed compares equal to one built
+// with an explicitly empty metadata map.
+func fieldOptionsEqual(a, b map[string]FieldOptions) bool {
+	for name, opt := range a {
+		if b[name] != opt {
+			return false
+		}
+	}
+	for name, opt := range b {
+		if a[name] != opt {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldOptionsTags(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := FieldOptions{Tag: "json=name omitempty=true"}
+	tags := opt.Tags()
+	assert.Equal("name", tags["json"])
+	assert.Equal("true", tags["omitempty"])
+
+	assert.Equal(map[string]string{}, FieldOptions{}.Tags())
+}
+
+func TestMakeStructTypeCompatibility(t *testing.T) {
+	assert := assert.New(t)
+
+	old := MakeStructType("S", TypeMap{"a": NumberType})
+	withOpts := MakeStructType("S", TypeMap{
+		"a": NumberType,
+		"b": StringType,
+	}, map[string]FieldOptions{
+		"b": {Optional: true},
+	})
+
+	requiresB := MakeStructType("S", TypeMap{
+		"a": NumberType,
+		"b": StringType,
+	})
+
+	assert.True(old.IsSubtypeOf(withOpts), "a struct missing an optional field is still a subtype")
+	assert.False(old.IsSubtypeOf(requiresB), "b is required here, so the struct without it is not a subtype")
+}
+
+func TestEqualsDistinguishesFieldOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	withOpts := MakeStructType("S", TypeMap{
+		"a": NumberType,
+		"b": StringType,
+	}, map[string]FieldOptions{
+		"b": {Optional: true},
+	})
+	requiresB := MakeStructType("S", TypeMap{
+		"a": NumberType,
+		"b": StringType,
+	})
+
+	assert.False(withOpts.Equals(requiresB), "differ only in whether b is Optional, so must not compare equal")
+	assert.False(requiresB.Equals(withOpts))
+	assert.True(withOpts.Equals(withOpts))
+
+	tagged := MakeStructType("S", TypeMap{
+		"a": NumberType,
+		"b": StringType,
+	}, map[string]FieldOptions{
+		"b": {Tag: "json=b"},
+	})
+	assert.False(requiresB.Equals(tagged), "differ only in Tag, so must not compare equal")
+}
@@ -0,0 +1,95 @@
+package types
+
+import "github.com/attic-labs/noms/d"
+
+// convertingList lazily adapts a List of one element type to present as a
+// List of another, converting each element as it is read rather than
+// copying the whole sequence up front.
+type convertingList struct {
+	src      List
+	elemType *Type
+	opts     ConvertOptions
+}
+
+func newConvertingList(src List, elemType *Type, opts ConvertOptions) List {
+	return convertingList{src, elemType, opts}
+}
+
+func (l convertingList) Type() *Type {
+	return MakeListType(l.elemType)
+}
+
+func (l convertingList) Len() uint64 {
+	return l.src.Len()
+}
+
+func (l convertingList) Get(idx uint64) Value {
+	converted, err := ConvertWithOptions(l.src.Get(idx), l.elemType, l.opts)
+	d.Chk.NoError(err, "convertingList: element %d: %v", idx, err)
+	return converted
+}
+
+func (l convertingList) IterAll(cb func(v Value, index uint64)) {
+	l.src.IterAll(func(v Value, index uint64) {
+		cb(l.Get(index), index)
+	})
+}
+
+// convertingSet lazily adapts a Set of one element type to present as a Set
+// of another, converting each element as it is read.
+type convertingSet struct {
+	src      Set
+	elemType *Type
+	opts     ConvertOptions
+}
+
+func newConvertingSet(src Set, elemType *Type, opts ConvertOptions) Set {
+	return convertingSet{src, elemType, opts}
+}
+
+func (s convertingSet) Type() *Type {
+	return MakeSetType(s.elemType)
+}
+
+func (s convertingSet) Len() uint64 {
+	return s.src.Len()
+}
+
+func (s convertingSet) IterAll(cb func(v Value)) {
+	s.src.IterAll(func(v Value) {
+		converted, err := ConvertWithOptions(v, s.elemType, s.opts)
+		d.Chk.NoError(err, "convertingSet: %v", err)
+		cb(converted)
+	})
+}
+
+// convertingMap lazily adapts a Map of one key/value type to present as a
+// Map of another, converting each entry as it is read.
+type convertingMap struct {
+	src     Map
+	keyType *Type
+	valType *Type
+	opts    ConvertOptions
+}
+
+func newConvertingMap(src Map, keyType, valType *Type, opts ConvertOptions) Map {
+	return convertingMap{src, keyType, valType, opts}
+}
+
+func (m convertingMap) Type() *Type {
+	return MakeMapType(m.keyType, m.valType)
+}
+
+func (m convertingMap) Len() uint64 {
+	return m.src.Len()
+}
+
+func (m convertingMap) IterAll(cb func(k, v Value)) {
+	m.src.IterAll(func(k, v Value) {
+		convertedKey, err := ConvertWithOptions(k, m.keyType, m.opts)
+		d.Chk.NoError(err, "convertingMap: key: %v", err)
+		convertedVal, err := ConvertWithOptions(v, m.valType, m.opts)
+		d.Chk.NoError(err, "convertingMap: value: %v", err)
+		cb(convertedKey, convertedVal)
+	})
+}
@@ -0,0 +1,126 @@
+package types
+
+import "github.com/attic-labs/noms/ref"
+
+// IsSubtypeOf returns true if a value of type t is always usable in a
+// context that expects a value of type other -- i.e. t is structurally
+// assignable to other. This is the basis for schema-evolution checks,
+// validating SchedulePut() payloads against the types promised by Hints,
+// and defining views over stored data without copying it.
+//
+// The rules, mirrored from the Noms type algebra:
+//   - every type is a subtype of Value, and of itself
+//   - List<A>, Set<A> and Ref<A> are covariant in A
+//   - Map<K, V> is covariant in both K and V
+//   - a union on the right is satisfied by any left type that is a subtype
+//     of one of its arms; a union on the left is a subtype of other iff
+//     every arm is
+//   - struct S{f1: T1, ...} is a subtype of struct S{g1: U1, ...} iff the
+//     names match and every field declared on the right is present on the
+//     left with a subtype of the declared type, or is missing on the left
+//     but marked Optional on the right (extra fields on the left are
+//     always allowed)
+//
+// Struct types may be recursive, so subtyping is checked co-inductively:
+// while proving sub <: super we optimistically assume sub <: super holds
+// and recurse, keyed on the pair of type refs, rather than looping forever.
+func (t *Type) IsSubtypeOf(other *Type) bool {
+	return isSubtypeOf(t, other, map[subtypePair]bool{})
+}
+
+// AssignableTo is a synonym for IsSubtypeOf, named for call sites that are
+// asking "can a value of type t be assigned where other is expected".
+func (t *Type) AssignableTo(other *Type) bool {
+	return t.IsSubtypeOf(other)
+}
+
+// subtypePair identifies an in-progress sub <: super proof by the refs of
+// the two types involved, so that recursive struct types terminate.
+type subtypePair struct {
+	sub, super ref.Ref
+}
+
+func isSubtypeOf(sub, super *Type, assumed map[subtypePair]bool) bool {
+	sub, super = sub.resolveAlias(), super.resolveAlias()
+
+	if sub.Equals(super) {
+		return true
+	}
+	if super.Kind() == ValueKind {
+		return true
+	}
+
+	// A union on the left is a subtype of other iff every arm is.
+	if subDesc, ok := sub.Desc.(CompoundDesc); ok && subDesc.Kind() == UnionKind {
+		for _, arm := range subDesc.ElemTypes {
+			if !isSubtypeOf(arm, super, assumed) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// A union on the right is satisfied by any arm sub is a subtype of.
+	if superDesc, ok := super.Desc.(CompoundDesc); ok && superDesc.Kind() == UnionKind {
+		for _, arm := range superDesc.ElemTypes {
+			if isSubtypeOf(sub, arm, assumed) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if sub.Kind() != super.Kind() {
+		return false
+	}
+
+	pair := subtypePair{sub.Ref(), super.Ref()}
+	if result, ok := assumed[pair]; ok {
+		return result
+	}
+	// Assume the relationship holds while we prove it, so that recursive
+	// struct types (e.g. a field that refers back to the struct itself)
+	// terminate instead of recursing forever.
+	assumed[pair] = true
+
+	result := computeSubtypeOf(sub, super, assumed)
+	assumed[pair] = result
+	return result
+}
+
+func computeSubtypeOf(sub, super *Type, assumed map[subtypePair]bool) bool {
+	switch superDesc := super.Desc.(type) {
+	case PrimitiveDesc:
+		// Kinds already matched above, and primitives carry no further
+		// structure to compare.
+		return true
+	case CompoundDesc:
+		subDesc := sub.Desc.(CompoundDesc)
+		for i, superElem := range superDesc.ElemTypes {
+			if !isSubtypeOf(subDesc.ElemTypes[i], superElem, assumed) {
+				return false
+			}
+		}
+		return true
+	case StructDesc:
+		subDesc, ok := sub.Desc.(StructDesc)
+		if !ok || subDesc.Name != superDesc.Name {
+			return false
+		}
+		for name, superFieldType := range superDesc.Fields {
+			subFieldType, present := subDesc.Fields[name]
+			if !present {
+				if superDesc.fieldOptions(name).Optional {
+					continue
+				}
+				return false
+			}
+			if !isSubtypeOf(subFieldType, superFieldType, assumed) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
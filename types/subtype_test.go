@@ -0,0 +1,76 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubtypePrimitives(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(BoolType.IsSubtypeOf(BoolType))
+	assert.True(NumberType.IsSubtypeOf(ValueType))
+	assert.True(StringType.IsSubtypeOf(ValueType))
+	assert.False(BoolType.IsSubtypeOf(NumberType))
+	assert.False(ValueType.IsSubtypeOf(BoolType))
+}
+
+func TestSubtypeCompound(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(MakeListType(NumberType).IsSubtypeOf(MakeListType(NumberType)))
+	assert.True(MakeListType(NumberType).IsSubtypeOf(MakeListType(ValueType)))
+	assert.False(MakeListType(ValueType).IsSubtypeOf(MakeListType(NumberType)))
+
+	assert.True(MakeMapType(StringType, NumberType).IsSubtypeOf(MakeMapType(ValueType, ValueType)))
+	assert.False(MakeMapType(ValueType, NumberType).IsSubtypeOf(MakeMapType(StringType, ValueType)))
+
+	assert.True(MakeRefType(NumberType).IsSubtypeOf(MakeRefType(ValueType)))
+}
+
+func TestSubtypeUnion(t *testing.T) {
+	assert := assert.New(t)
+
+	u := MakeUnionType(NumberType, StringType)
+	assert.True(NumberType.IsSubtypeOf(u))
+	assert.True(StringType.IsSubtypeOf(u))
+	assert.False(BoolType.IsSubtypeOf(u))
+	assert.True(u.IsSubtypeOf(ValueType))
+	assert.False(u.IsSubtypeOf(NumberType))
+}
+
+func TestSubtypeStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	wide := MakeStructType("S", TypeMap{
+		"a": NumberType,
+		"b": StringType,
+	})
+	narrow := MakeStructType("S", TypeMap{
+		"a": NumberType,
+	})
+	mismatched := MakeStructType("S", TypeMap{
+		"a": StringType,
+	})
+	otherName := MakeStructType("T", TypeMap{
+		"a": NumberType,
+	})
+
+	assert.True(wide.IsSubtypeOf(narrow))
+	assert.False(narrow.IsSubtypeOf(wide))
+	assert.False(mismatched.IsSubtypeOf(narrow))
+	assert.False(narrow.IsSubtypeOf(otherName))
+}
+
+func TestSubtypeRecursiveStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	recType := MakeStructType("RecursiveStruct", TypeMap{
+		"self": nil,
+	})
+	recType.Desc.(StructDesc).Fields["self"] = recType
+
+	assert.True(recType.IsSubtypeOf(recType))
+	assert.True(recType.AssignableTo(recType))
+}
@@ -46,12 +46,38 @@ func (t *Type) Name() string {
 	return t.Desc.(StructDesc).Name
 }
 
+// Ref hashes t's encoded form. NOTE: the encoder that produces that form
+// lives outside this package snapshot, and FieldOptions was added to
+// StructDesc without a corresponding update there -- two StructDescs that
+// differ only in a field's Optional/Tag currently encode, and therefore
+// hash, identically. Equals compensates for that below so it doesn't paper
+// over the difference, but the ref itself is still blind to it until the
+// encoder is updated to serialize FieldOptions.
 func (t *Type) Ref() ref.Ref {
 	return EnsureRef(t.ref, t)
 }
 
 func (t *Type) Equals(other Value) (res bool) {
-	return other != nil && t.Ref() == other.Ref()
+	if other == nil {
+		return false
+	}
+	self := t.resolveAlias()
+	ot, ok := other.(*Type)
+	if !ok {
+		return self.Ref() == other.Ref()
+	}
+	ot = ot.resolveAlias()
+	if self.Ref() != ot.Ref() {
+		return false
+	}
+
+	// Ref doesn't yet reflect FieldOptions (see the note on Ref above), so
+	// two struct types whose fields are only distinguished by Optional/Tag
+	// would otherwise compare equal despite differing in subtyping
+	// behavior (see TestEqualsDistinguishesFieldOptions).
+	sd, sIsStruct := self.Desc.(StructDesc)
+	od, oIsStruct := ot.Desc.(StructDesc)
+	return sIsStruct == oIsStruct && (!sIsStruct || fieldOptionsEqual(sd.FieldOptions, od.FieldOptions))
 }
 
 func (t *Type) Chunks() (chunks []Ref) {
@@ -65,9 +91,11 @@ func (t *Type) ChildValues() (res []Value) {
 			res = append(res, t)
 		}
 	case StructDesc:
-		desc.IterFields(func(name string, t *Type) {
+		desc.IterFields(func(name string, t *Type, opt FieldOptions) {
 			res = append(res, t)
 		})
+	case AliasDesc:
+		res = append(res, desc.Target)
 	case PrimitiveDesc:
 		// Nothing, these have no child values
 	default:
@@ -124,11 +152,20 @@ func MakePrimitiveTypeByString(p string) *Type {
 	return nil
 }
 
-func MakeStructType(name string, fields map[string]*Type) *Type {
+// MakeStructType builds a struct Type named name with the given fields. The
+// optional fieldOptions argument attaches per-field metadata (Optional,
+// Tag) keyed by field name; fields absent from it are required and
+// untagged, which is also how structs built before FieldOptions existed are
+// treated when their chunks are decoded.
+func MakeStructType(name string, fields TypeMap, fieldOptions ...map[string]FieldOptions) *Type {
 	for fn := range fields {
 		verifyFieldName(fn)
 	}
-	return buildType(StructDesc{name, fields})
+	desc := StructDesc{name, fields, nil}
+	if len(fieldOptions) > 0 {
+		desc.FieldOptions = fieldOptions[0]
+	}
+	return buildType(desc)
 }
 
 var fieldNameRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"sync"
+
+	"github.com/attic-labs/noms/ref"
+)
+
+// TypeRegistry lets a struct type that refers to itself -- or one shared by
+// many values -- be declared once and looked up by name, rather than built
+// with the "nil placeholder, then mutate StructDesc.Fields" pattern a
+// recursive type otherwise requires. A ValueStore normally owns one
+// TypeRegistry, so that every value it reads or writes resolves a given
+// name to the same *Type instance.
+type TypeRegistry struct {
+	mu    sync.Mutex
+	types map[string]*Type
+}
+
+// NewTypeRegistry returns an empty TypeRegistry.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{types: map[string]*Type{}}
+}
+
+// Declare builds the Type registered under name, or returns it unchanged if
+// name has already been declared. build is handed a forward reference to
+// the Type under construction -- self -- so it can embed a reference to
+// the type being built (e.g. a field typed as the struct itself) without
+// the caller mutating Desc.Fields after the fact. Once build returns, self
+// is finalized in place to hold the built Type's Desc, so any reference to
+// self captured during build now observes the finished type.
+func (r *TypeRegistry) Declare(name string, build func(self *Type) *Type) *Type {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t, ok := r.types[name]; ok {
+		return t
+	}
+
+	self := &Type{Desc: StructDesc{name, TypeMap{}, nil}, ref: &ref.Ref{}}
+	r.types[name] = self
+
+	built := build(self)
+	*self = *built
+	return self
+}
+
+// Lookup returns the Type previously registered under name via Declare, and
+// false if name has not been declared.
+func (r *TypeRegistry) Lookup(name string) (*Type, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// AliasDesc names a Target Type. It carries no structure of its own --
+// Kind() defers to Target -- so code that switches on Desc.Kind() treats an
+// alias exactly like the type it names; only code that cares about display
+// or encoding needs to know Name.
+type AliasDesc struct {
+	Name   string
+	Target *Type
+}
+
+func (a AliasDesc) Kind() NomsKind {
+	return a.Target.Kind()
+}
+
+// MakeAliasType returns a Type that behaves exactly like target wherever
+// types are compared or checked -- Equals and IsSubtypeOf resolve straight
+// through it -- but keeps name visible in Describe() output and in the
+// encoded type, so a reader sees e.g. "Person" rather than the fully
+// expanded struct. The alias holds target directly; it is not linked to
+// any TypeRegistry, and TypeRegistry itself only maps names to Types in
+// memory.
+func MakeAliasType(name string, target *Type) *Type {
+	return buildType(AliasDesc{name, target})
+}
+
+// resolveAlias follows a chain of aliases down to the first non-alias
+// Type, so that Equals and IsSubtypeOf can treat an alias as wholly
+// transparent.
+func (t *Type) resolveAlias() *Type {
+	for {
+		alias, ok := t.Desc.(AliasDesc)
+		if !ok {
+			return t
+		}
+		t = alias.Target
+	}
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeRegistryDeclareRecursive(t *testing.T) {
+	assert := assert.New(t)
+
+	reg := NewTypeRegistry()
+	personType := reg.Declare("Person", func(self *Type) *Type {
+		return MakeStructType("Person", TypeMap{
+			"name":   StringType,
+			"friend": self,
+		})
+	})
+
+	assert.Equal("Person", personType.Name())
+	friendType := personType.Desc.(StructDesc).Fields["friend"]
+	assert.True(personType.Equals(friendType))
+}
+
+func TestTypeRegistryDeclareIsIdempotent(t *testing.T) {
+	assert := assert.New(t)
+
+	reg := NewTypeRegistry()
+	calls := 0
+	build := func(self *Type) *Type {
+		calls++
+		return MakeStructType("Widget", TypeMap{"id": NumberType})
+	}
+
+	first := reg.Declare("Widget", build)
+	second := reg.Declare("Widget", build)
+
+	assert.True(first.Equals(second))
+	assert.Equal(1, calls)
+}
+
+func TestTypeRegistryLookup(t *testing.T) {
+	assert := assert.New(t)
+
+	reg := NewTypeRegistry()
+	_, ok := reg.Lookup("Missing")
+	assert.False(ok)
+
+	declared := reg.Declare("Widget", func(self *Type) *Type {
+		return MakeStructType("Widget", TypeMap{"id": NumberType})
+	})
+	found, ok := reg.Lookup("Widget")
+	assert.True(ok)
+	assert.True(declared.Equals(found))
+}
+
+func TestAliasTypeTransparency(t *testing.T) {
+	assert := assert.New(t)
+
+	target := MakeStructType("Person", TypeMap{"name": StringType})
+	alias := MakeAliasType("Person", target)
+
+	assert.True(alias.Equals(target))
+	assert.True(target.Equals(alias))
+	assert.True(alias.IsSubtypeOf(target))
+	assert.True(target.IsSubtypeOf(alias))
+	assert.True(alias.IsSubtypeOf(ValueType))
+}